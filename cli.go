@@ -9,29 +9,60 @@ import (
 	"os"
 )
 
+// loadConfigFrom loads rules from configPath if given, falling back to the
+// embedded default config otherwise.
+func loadConfigFrom(configPath string) error {
+	data := DefaultCfgData
+	if configPath != "" {
+		data = mustReadConfig(configPath)
+		fmt.Printf("Loaded config from %s\n", configPath)
+	} else {
+		fmt.Printf("Using inbuild config with %d bytes\n", len(data))
+	}
+
+	if err := LoadRules(data); err != nil {
+		return err
+	}
+	fmt.Printf("Loaded config with %d sites\n", len(GetRules().Sites))
+	return nil
+}
+
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint", "test":
+			if err := runLint(os.Args[2:]); err != nil {
+				fmt.Fprintln(os.Stderr, "lint error:", err)
+				os.Exit(1)
+			}
+			return
+		}
+	}
+
 	configPath := flag.String("config", "", "Path to YAML configuration file")
 	urlInput := flag.String("url", "", "URL to process")
 	signFlag := flag.Bool("sign", false, "Generate signature of the final URL")
+	serveFlag := flag.Bool("serve", false, "Run as an HTTP server instead of processing a single URL")
+	listenAddr := flag.String("listen", ":8080", "Address to listen on when -serve is set")
 	flag.Parse()
 
-	if *urlInput == "" {
-		fmt.Println("URL input is required")
-		flag.PrintDefaults()
+	if err := loadConfigFrom(*configPath); err != nil {
+		fmt.Println("Failed to load config:", err)
 		os.Exit(1)
 	}
-	if configPath != nil && *configPath != "" {
-		DefaultCfgData = mustReadConfig(*configPath)
-		fmt.Printf("Loaded config from %s\n", *configPath)
-	} else {
-		fmt.Printf("Using inbuild config with %d bytes\n", len(DefaultCfgData))
+
+	if *serveFlag {
+		if err := runServer(*listenAddr, *configPath); err != nil {
+			fmt.Fprintln(os.Stderr, "Server error:", err)
+			os.Exit(1)
+		}
+		return
 	}
-	err := LoadRules(DefaultCfgData)
-	if err != nil {
-		fmt.Println("Failed to load config: %v", err)
+
+	if *urlInput == "" {
+		fmt.Println("URL input is required")
+		flag.PrintDefaults()
 		os.Exit(1)
-	} else {
-		fmt.Println("Loaded config with %d sites", len(Rules.Sites))
 	}
 
 	formattedURL, err := processURL(*urlInput)
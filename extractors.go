@@ -0,0 +1,377 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Extractor pulls one or more named values out of a URL part, inspired by
+// nuclei's extractors. Internal extractors are available to sibling
+// extractors within the same TemplateRule but are stripped from the fields
+// handed to the final template.
+type Extractor struct {
+	Type     string `yaml:"type"`     // regex, kval, json, xpath
+	Part     string `yaml:"part"`     // path, query, fragment, host, full
+	Group    string `yaml:"group"`    // output field name
+	Internal bool   `yaml:"internal"` // keep out of the final template context
+	Value    string `yaml:"value"`    // regex pattern / query key / json path / xpath expression, depending on Type
+
+	_Regex *regexp.Regexp
+}
+
+// Matcher gates extraction: a TemplateRule with matchers only runs its
+// extractors once those matchers pass (per Condition).
+type Matcher struct {
+	Type  string   `yaml:"type"`  // word, regex, dsl
+	Part  string   `yaml:"part"`  // path, query, fragment, host, full
+	Words []string `yaml:"words"` // candidates for type: word
+	Value string   `yaml:"value"` // pattern for type: regex, expression for type: dsl
+
+	_Regex *regexp.Regexp
+}
+
+// resolveContent returns the string an extractor should run against: one of
+// the well-known URL parts, or — if part names an earlier extractor's
+// Group — that already-extracted value. This is how an internal extractor's
+// output becomes available to its sibling extractors in the same rule.
+func resolveContent(u *url.URL, part string, fields map[string]string) string {
+	if isRawURLPart(part) {
+		return urlPart(u, part)
+	}
+	if val, ok := fields[part]; ok {
+		return val
+	}
+	return urlPart(u, part)
+}
+
+// isRawURLPart reports whether part names one of the well-known,
+// still-percent-encoded URL parts resolveContent reads straight off the
+// URL, as opposed to a Group reference to an earlier extractor's output
+// (which may already be decoded, e.g. a kval extractor's url.Values.Get).
+func isRawURLPart(part string) bool {
+	switch part {
+	case "path", "query", "fragment", "host", "full", "":
+		return true
+	}
+	return false
+}
+
+// migrateLegacyExtractors turns a TemplateRule's Pattern and QueryParams
+// fields into the equivalent synthetic extractors, so rules written before
+// the extractors/matchers DSL keep behaving exactly as before.
+func migrateLegacyExtractors(rule TemplateRule) []Extractor {
+	var extractors []Extractor
+
+	if rule.Pattern != "" {
+		extractors = append(extractors, Extractor{
+			Type:  "regex",
+			Part:  "path",
+			Value: rule.Pattern,
+		})
+	}
+
+	for field, queryParam := range rule.QueryParams {
+		extractors = append(extractors, Extractor{
+			Type:  "kval",
+			Part:  "query",
+			Group: field,
+			Value: queryParam,
+		})
+	}
+
+	return extractors
+}
+
+// urlPart returns the raw string content of the named URL part.
+func urlPart(u *url.URL, part string) string {
+	switch part {
+	case "path":
+		return u.Path
+	case "query":
+		return u.RawQuery
+	case "fragment":
+		return u.Fragment
+	case "host":
+		return u.Host
+	default: // "full" and unrecognized parts fall back to the whole URL
+		return u.String()
+	}
+}
+
+// compileExtractor pre-compiles any regex an extractor needs.
+func compileExtractor(e *Extractor, domain string) error {
+	if e.Type == "regex" {
+		re, err := regexp.Compile(e.Value)
+		if err != nil {
+			return fmt.Errorf("compiling extractor regex for domain %s: %w", domain, err)
+		}
+		e._Regex = re
+	}
+	return nil
+}
+
+// compileMatcher pre-compiles any regex a matcher needs.
+func compileMatcher(m *Matcher, domain string) error {
+	if m.Type == "regex" {
+		re, err := regexp.Compile(m.Value)
+		if err != nil {
+			return fmt.Errorf("compiling matcher regex for domain %s: %w", domain, err)
+		}
+		m._Regex = re
+	}
+	return nil
+}
+
+// runMatchers reports whether a rule's matchers allow extraction to
+// proceed. A rule with no matchers always passes.
+func runMatchers(u *url.URL, matchers []Matcher, condition string) bool {
+	if len(matchers) == 0 {
+		return true
+	}
+	and := condition == "and"
+	for _, m := range matchers {
+		ok := matchOne(urlPart(u, m.Part), m)
+		if and && !ok {
+			return false
+		}
+		if !and && ok {
+			return true
+		}
+	}
+	return and
+}
+
+func matchOne(content string, m Matcher) bool {
+	switch m.Type {
+	case "word":
+		for _, w := range m.Words {
+			if strings.Contains(content, w) {
+				return true
+			}
+		}
+		return false
+	case "regex":
+		return m._Regex != nil && m._Regex.MatchString(content)
+	case "dsl":
+		// Minimal DSL: contains("substr"). Enough for simple presence
+		// checks without pulling in a full expression evaluator.
+		expr := strings.TrimSpace(m.Value)
+		if strings.HasPrefix(expr, "contains(") && strings.HasSuffix(expr, ")") {
+			needle := strings.Trim(expr[len("contains("):len(expr)-1], `"'`)
+			return strings.Contains(content, needle)
+		}
+		return false
+	default:
+		return false
+	}
+}
+
+// runExtractors evaluates extractors against u in declaration order,
+// returning the union of their output fields with internal ones stripped.
+func runExtractors(u *url.URL, extractors []Extractor) (map[string]string, error) {
+	fields := make(map[string]string)
+	internal := make(map[string]bool)
+
+	for _, e := range extractors {
+		content := resolveContent(u, e.Part, fields)
+
+		switch e.Type {
+		case "regex":
+			extractRegex(e, content, fields, internal)
+		case "kval":
+			extractKval(e, content, fields, internal)
+		case "json":
+			extractJSON(e, content, fields, internal)
+		case "xpath":
+			extractXPath(e, content, fields, internal)
+		}
+	}
+
+	for name := range internal {
+		delete(fields, name)
+	}
+
+	if len(fields) == 0 {
+		return nil, fmt.Errorf("no fields extracted from URL: %s", u.String())
+	}
+	return fields, nil
+}
+
+func store(fields map[string]string, internal map[string]bool, e Extractor, name, value string) {
+	fields[name] = value
+	if e.Internal {
+		internal[name] = true
+	}
+}
+
+func extractRegex(e Extractor, content string, fields map[string]string, internal map[string]bool) {
+	if e._Regex == nil {
+		return
+	}
+	matches := e._Regex.FindStringSubmatch(content)
+	if matches == nil {
+		return
+	}
+
+	named := false
+	for i, name := range e._Regex.SubexpNames() {
+		if i > 0 && name != "" && matches[i] != "" {
+			store(fields, internal, e, name, matches[i])
+			named = true
+		}
+	}
+	// No named group captured a value: fall back to a single value under
+	// Group (or the whole match if the pattern has no groups at all).
+	if !named && e.Group != "" {
+		value := matches[0]
+		if len(matches) > 1 {
+			value = matches[1]
+		}
+		store(fields, internal, e, e.Group, value)
+	}
+}
+
+func extractKval(e Extractor, content string, fields map[string]string, internal map[string]bool) {
+	if e.Group == "" {
+		return
+	}
+	// ParseQuery keeps every well-formed pair even when it reports an error
+	// for another one, matching url.URL.Query().
+	values, _ := url.ParseQuery(content)
+	if val := values.Get(e.Value); val != "" {
+		store(fields, internal, e, e.Group, val)
+	}
+}
+
+func extractJSON(e Extractor, content string, fields map[string]string, internal map[string]bool) {
+	if e.Group == "" {
+		return
+	}
+	if isRawURLPart(e.Part) {
+		content = unescapePart(content)
+	}
+	val, err := jsonPathLookup(content, e.Value)
+	if err != nil {
+		return
+	}
+	store(fields, internal, e, e.Group, val)
+}
+
+// unescapePart best-effort percent-decodes a raw URL part (e.g. RawQuery)
+// before it's handed to a JSON or XPath parser. Only called for raw URL parts - a Group reference to an
+// earlier extractor's output may already be decoded, and unescaping it
+// again would corrupt any literal "%XX"-looking or "+" content within.
+// Falls back to the original string if decoding fails.
+func unescapePart(content string) string {
+	if decoded, err := url.QueryUnescape(content); err == nil {
+		return decoded
+	}
+	return content
+}
+
+// jsonPathLookup walks a dot-separated path (e.g. "user.id") into a JSON
+// document and returns the leaf value formatted as a string.
+func jsonPathLookup(content, path string) (string, error) {
+	var doc any
+	if err := json.Unmarshal([]byte(content), &doc); err != nil {
+		return "", err
+	}
+	for _, key := range strings.Split(path, ".") {
+		obj, ok := doc.(map[string]any)
+		if !ok {
+			return "", fmt.Errorf("json path %q: %q is not an object", path, key)
+		}
+		doc, ok = obj[key]
+		if !ok {
+			return "", fmt.Errorf("json path %q: key %q not found", path, key)
+		}
+	}
+	return fmt.Sprintf("%v", doc), nil
+}
+
+func extractXPath(e Extractor, content string, fields map[string]string, internal map[string]bool) {
+	if e.Group == "" {
+		return
+	}
+	if isRawURLPart(e.Part) {
+		content = unescapePart(content)
+	}
+	val, err := xpathLookup(content, e.Value)
+	if err != nil {
+		return
+	}
+	store(fields, internal, e, e.Group, val)
+}
+
+// xpathLookup supports a small, practical subset of XPath: "//tag" (the
+// first matching element's text) and "//tag/@attr" (that element's
+// attribute value). It is not a general XPath evaluator.
+func xpathLookup(content, expr string) (string, error) {
+	tag, attr, ok := parseSimpleXPath(expr)
+	if !ok {
+		return "", fmt.Errorf("unsupported xpath expression: %s", expr)
+	}
+
+	doc, err := html.Parse(strings.NewReader(content))
+	if err != nil {
+		return "", err
+	}
+
+	found := findElement(doc, tag)
+	if found == nil {
+		return "", fmt.Errorf("xpath %q: no match", expr)
+	}
+	if attr != "" {
+		for _, a := range found.Attr {
+			if a.Key == attr {
+				return a.Val, nil
+			}
+		}
+		return "", fmt.Errorf("xpath %q: attribute %q not found", expr, attr)
+	}
+	return elementText(found), nil
+}
+
+func findElement(n *html.Node, tag string) *html.Node {
+	if n.Type == html.ElementNode && n.Data == tag {
+		return n
+	}
+	for c := n.FirstChild; c != nil; c = c.NextSibling {
+		if found := findElement(c, tag); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+func elementText(n *html.Node) string {
+	var sb strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		if n.Type == html.TextNode {
+			sb.WriteString(n.Data)
+		}
+		for c := n.FirstChild; c != nil; c = c.NextSibling {
+			walk(c)
+		}
+	}
+	walk(n)
+	return sb.String()
+}
+
+func parseSimpleXPath(expr string) (tag, attr string, ok bool) {
+	expr = strings.TrimPrefix(strings.TrimSpace(expr), "//")
+	parts := strings.SplitN(expr, "/@", 2)
+	if parts[0] == "" {
+		return "", "", false
+	}
+	if len(parts) == 2 {
+		return parts[0], parts[1], true
+	}
+	return parts[0], "", true
+}
@@ -0,0 +1,158 @@
+package main
+
+import (
+	"net/url"
+	"testing"
+)
+
+func TestLoadRulesExtractorKinds(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: example.com
+    stop_at_first_match: false
+    templates:
+      - template: "https://example.com/regex/{{.Slug}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+      - template: "https://example.com/kval/{{.ID}}"
+        extractors:
+          - type: kval
+            part: query
+            group: ID
+            value: id
+      - template: "https://example.com/json/{{.City}}"
+        extractors:
+          - type: kval
+            part: query
+            group: Payload
+            internal: true
+            value: data
+          - type: json
+            part: Payload
+            group: City
+            value: address.city
+      - template: "https://example.com/xpath/{{.Title}}"
+        matchers:
+          - type: word
+            part: path
+            words: ["/page"]
+        extractors:
+          - type: kval
+            part: query
+            group: Page
+            internal: true
+            value: html
+          - type: xpath
+            part: Page
+            group: Title
+            value: "//h1"
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	jsonURL := "https://example.com/whatever?" + url.Values{
+		"data": {`{"address":{"city":"Helsinki"}}`},
+	}.Encode()
+	xpathURL := "https://example.com/page?" + url.Values{
+		"html": {"<h1>Title</h1>"},
+	}.Encode()
+
+	cases := []struct {
+		name string
+		url  string
+		want string
+	}{
+		{"regex", "https://example.com/articles/hello-world", "https://example.com/regex/hello-world"},
+		{"kval", "https://example.com/whatever?id=42", "https://example.com/kval/42"},
+		{"json", jsonURL, "https://example.com/json/Helsinki"},
+		{"xpath", xpathURL, "https://example.com/xpath/Title"},
+	}
+
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := processURL(tc.url)
+			if err != nil {
+				t.Fatalf("processURL(%q) failed: %v", tc.url, err)
+			}
+			if got != tc.want {
+				t.Fatalf("processURL(%q) = %q, want %q", tc.url, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestRunMatchersCondition(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: news.example
+    templates:
+      - template: "https://news.example/{{.Slug}}"
+        matchers_condition: and
+        matchers:
+          - type: word
+            part: path
+            words: ["/news"]
+          - type: regex
+            part: path
+            value: "/\\d+-"
+        extractors:
+          - type: regex
+            part: path
+            value: "/\\d+-(?P<Slug>[^/]+)"
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	if _, err := processURL("https://news.example/opinion/123-no-match"); err == nil {
+		t.Fatalf("expected no match for a path missing the /news word")
+	}
+	got, err := processURL("https://news.example/news/123-great-story")
+	if err != nil {
+		t.Fatalf("processURL failed: %v", err)
+	}
+	if want := "https://news.example/great-story"; got != want {
+		t.Fatalf("processURL = %q, want %q", got, want)
+	}
+}
+
+// TestExtractJSONGroupNotDoubleUnescaped guards against re-applying
+// url.QueryUnescape to a Group reference that a kval extractor already
+// decoded: a literal "%20"-looking substring in the decoded JSON payload
+// must survive untouched, not get unescaped a second time into a space.
+func TestExtractJSONGroupNotDoubleUnescaped(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: example.com
+    templates:
+      - template: "https://example.com/deal/{{.Title}}"
+        extractors:
+          - type: kval
+            part: query
+            group: Payload
+            internal: true
+            value: data
+          - type: json
+            part: Payload
+            group: Title
+            value: title
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	reqURL := "https://example.com/whatever?" + url.Values{
+		"data": {`{"title":"50%20off"}`},
+	}.Encode()
+
+	got, err := processURL(reqURL)
+	if err != nil {
+		t.Fatalf("processURL(%q) failed: %v", reqURL, err)
+	}
+	if want := "https://example.com/deal/50%20off"; got != want {
+		t.Fatalf("processURL(%q) = %q, want %q (Group content was double-unescaped)", reqURL, got, want)
+	}
+}
@@ -1,16 +1,16 @@
 package main
 
 import (
-	"crypto/sha256"
 	_ "embed"
-	"encoding/hex"
 	"fmt"
 	"io"
 	"net/url"
 	"os"
 	"regexp"
 	"strings"
+	"sync"
 	"text/template"
+	"time"
 
 	"github.com/PuerkitoBio/purell"
 	"gopkg.in/yaml.v2"
@@ -22,14 +22,25 @@ type TemplateRule struct {
 	QueryParams map[string]string `yaml:"query_params"` // Query parameters to extract
 	Template    string            `yaml:"template"`     // URL template to generate final URL
 	Transform   map[string]string `yaml:"transform"`    // Field transformations (e.g., lowercase)
-	_Regex      *regexp.Regexp    // Compiled regex
-	_Template   *template.Template
+
+	// Extractors and Matchers are a nuclei-inspired DSL layered on top of
+	// the legacy Pattern/QueryParams fields above. LoadRules auto-migrates
+	// Pattern and QueryParams into synthetic extractors so old YAML keeps
+	// working unchanged; Extractors declared here run alongside them.
+	Extractors        []Extractor `yaml:"extractors"`
+	Matchers          []Matcher   `yaml:"matchers"`           // Must pass before extraction runs
+	MatchersCondition string      `yaml:"matchers_condition"` // and|or, defaults to or
+
+	_Regex     *regexp.Regexp // Compiled regex
+	_Template  *template.Template
+	_Extractor []Extractor // Pattern/QueryParams + Extractors, compiled and merged
 }
 
 type RuleTestCase struct {
 	Url       string `yaml:"url"`
 	Expected  string `yaml:"expected"`
 	Signature string `yaml:"signature,omitempty"`
+	XFail     bool   `yaml:"xfail,omitempty"` // Case is expected to fail to match (negative test)
 }
 
 // SiteRule holds all extraction templates for a site
@@ -37,28 +48,75 @@ type SiteRule struct {
 	Domain    string         `yaml:"domain"`    // Domain this applies to
 	Templates []TemplateRule `yaml:"templates"` // Multiple extraction templates
 	Tests     []RuleTestCase `yaml:"tests"`     // Tests for this rule
+
+	// StopAtFirstMatch controls whether processURL returns as soon as one
+	// template matches, or evaluates every template and returns the last
+	// successful result. Unset (nil) defaults to true, preserving the
+	// original stop-on-first-match behavior for existing YAML.
+	StopAtFirstMatch *bool `yaml:"stop_at_first_match"`
+
+	// Signature overrides the global signing algorithm for formatted URLs
+	// whose host ends in Domain; the first such site with a Signature block
+	// wins. Nil falls back to Config.Signature.
+	Signature *SignatureConfig `yaml:"signature"`
+}
+
+func (s SiteRule) stopAtFirstMatch() bool {
+	return s.StopAtFirstMatch == nil || *s.StopAtFirstMatch
 }
 
 type Config struct {
 	Sites []SiteRule `yaml:"sites"`
+
+	// Signature is the default signing algorithm for sites that don't
+	// declare their own `signature` block.
+	Signature SignatureConfig `yaml:"signature"`
 }
 
 //go:embed rules.yaml
 var DefaultCfgData []byte
 
-var Rules *Config
+var (
+	rulesMu sync.RWMutex
+	rules   *Config
+)
 
-// Read config from file
-func mustReadConfig(path string) []byte {
+// GetRules returns the currently active rule set. Safe for concurrent use
+// while LoadRules (or a hot-reload) swaps the rules out from under it.
+func GetRules() *Config {
+	rulesMu.RLock()
+	defer rulesMu.RUnlock()
+	return rules
+}
+
+// metricsHook, when non-nil, is called after every processURL attempt so a
+// platform-specific front-end (e.g. the HTTP server) can record per-domain
+// and latency metrics without lib.go depending on a metrics library.
+var metricsHook func(domain string, matched bool, templateErr bool, duration time.Duration)
+
+// readConfig reads path, returning an error instead of panicking. Use this
+// anywhere a read failure must not take the caller down with it, e.g. the
+// server's hot-reload watcher.
+func readConfig(path string) ([]byte, error) {
 	f, err := os.Open(path)
 	if err != nil {
-		fmt.Printf("Failed to open config file: %v\n", err)
-		panic(err)
+		return nil, fmt.Errorf("opening config file: %w", err)
 	}
 	defer f.Close()
 	data, err := io.ReadAll(f)
 	if err != nil {
-		fmt.Printf("Failed to read config file: %v\n", err)
+		return nil, fmt.Errorf("reading config file: %w", err)
+	}
+	return data, nil
+}
+
+// mustReadConfig reads path, panicking on failure. Only safe at startup
+// (cli.go, wasi.go's main), where there's no live server to keep running
+// through an error.
+func mustReadConfig(path string) []byte {
+	data, err := readConfig(path)
+	if err != nil {
+		fmt.Println("Failed to read config file:", err)
 		panic(err)
 	}
 	return data
@@ -73,24 +131,44 @@ func LoadRules(data []byte) error {
 
 	// Compile regex and parse templates
 	for i := range cfg.Sites {
+		domain := cfg.Sites[i].Domain
 		for j := range cfg.Sites[i].Templates {
-			tmpl, err := template.New("urlTemplate").Parse(cfg.Sites[i].Templates[j].Template)
+			rule := &cfg.Sites[i].Templates[j]
+
+			tmpl, err := template.New("urlTemplate").Parse(rule.Template)
 			if err != nil {
-				return fmt.Errorf("parsing template for domain %s: %w", cfg.Sites[i].Domain, err)
+				return fmt.Errorf("parsing template for domain %s: %w", domain, err)
 			}
-			cfg.Sites[i].Templates[j]._Template = tmpl
+			rule._Template = tmpl
 
-			if cfg.Sites[i].Templates[j].Pattern != "" {
-				re, err := regexp.Compile(cfg.Sites[i].Templates[j].Pattern)
+			if rule.Pattern != "" {
+				re, err := regexp.Compile(rule.Pattern)
 				if err != nil {
-					return fmt.Errorf("compiling regex for domain %s: %w", cfg.Sites[i].Domain, err)
+					return fmt.Errorf("compiling regex for domain %s: %w", domain, err)
+				}
+				rule._Regex = re
+			}
+
+			extractors := migrateLegacyExtractors(*rule)
+			extractors = append(extractors, rule.Extractors...)
+			for k := range extractors {
+				if err := compileExtractor(&extractors[k], domain); err != nil {
+					return err
+				}
+			}
+			rule._Extractor = extractors
+
+			for k := range rule.Matchers {
+				if err := compileMatcher(&rule.Matchers[k], domain); err != nil {
+					return err
 				}
-				cfg.Sites[i].Templates[j]._Regex = re
 			}
 		}
 	}
 
-	Rules = &cfg
+	rulesMu.Lock()
+	rules = &cfg
+	rulesMu.Unlock()
 
 	return nil
 }
@@ -100,29 +178,13 @@ func normalizeURL(rawURL string) (string, error) {
 	return purell.NormalizeURLString(rawURL, purell.FlagsSafe|purell.FlagRemoveDotSegments|purell.FlagSortQuery)
 }
 
-// Extract fields using regex and query parameters
+// Extract fields by running the rule's compiled extractor pipeline
+// (Pattern/QueryParams auto-migrated plus any declared Extractors), then
+// applying field transformations.
 func extractFields(u *url.URL, rule TemplateRule) (map[string]string, error) {
-	fields := make(map[string]string)
-
-	// Extract using regex
-	if rule._Regex != nil {
-		matches := rule._Regex.FindStringSubmatch(u.Path)
-		if matches == nil {
-			fmt.Printf("No matches found in path '%s' for pattern '%s'\n", u.Path, rule._Regex.String())
-		} else {
-			for i, name := range rule._Regex.SubexpNames() {
-				if i > 0 && name != "" && matches[i] != "" {
-					fields[name] = matches[i]
-				}
-			}
-		}
-	}
-
-	// Extract using query parameters
-	for field, qp := range rule.QueryParams {
-		if val := u.Query().Get(qp); val != "" {
-			fields[field] = val
-		}
+	fields, err := runExtractors(u, rule._Extractor)
+	if err != nil {
+		return nil, err
 	}
 
 	// Apply transformations (e.g., lowercase)
@@ -135,9 +197,6 @@ func extractFields(u *url.URL, rule TemplateRule) (map[string]string, error) {
 		}
 	}
 
-	if len(fields) == 0 {
-		return nil, fmt.Errorf("no fields extracted from URL: %s", u.String())
-	}
 	return fields, nil
 }
 
@@ -152,6 +211,16 @@ func formatURL(u *url.URL, rule TemplateRule, fields map[string]string) (string,
 
 // Process a given URL and match it with site rules
 func processURL(inputURL string) (string, error) {
+	start := time.Now()
+	host := ""
+	matched := false
+	templateErr := false
+	if metricsHook != nil {
+		defer func() {
+			metricsHook(host, matched, templateErr, time.Since(start))
+		}()
+	}
+
 	normalizedURL, err := normalizeURL(inputURL)
 	if err != nil {
 		return "", err
@@ -165,30 +234,48 @@ func processURL(inputURL string) (string, error) {
 
 	// Assuming normalization removes "www." if needed.
 	//host := strings.TrimPrefix(parsed.Host, "www.")
-	host := parsed.Host
-
-	for _, site := range Rules.Sites {
-		if strings.HasSuffix(host, site.Domain) {
-			for _, rule := range site.Templates {
-				if rule._Regex == nil || rule._Regex.MatchString(parsed.Path) {
-					fields, err := extractFields(parsed, rule)
-					if err != nil {
-						continue
-					}
-					return formatURL(parsed, rule, fields)
+	host = parsed.Host
+
+	var lastResult string
+	for _, site := range GetRules().Sites {
+		if !strings.HasSuffix(host, site.Domain) {
+			continue
+		}
+		for _, rule := range site.Templates {
+			if rule._Regex != nil && !rule._Regex.MatchString(parsed.Path) {
+				continue
+			}
+			if !runMatchers(parsed, rule.Matchers, rule.MatchersCondition) {
+				continue
+			}
+
+			fields, err := extractFields(parsed, rule)
+			if err != nil {
+				continue
+			}
+			formatted, err := formatURL(parsed, rule, fields)
+			if err != nil {
+				templateErr = true
+				if site.stopAtFirstMatch() {
+					return "", err
 				}
+				continue
+			}
+
+			matched = true
+			templateErr = false
+			lastResult = formatted
+			if site.stopAtFirstMatch() {
+				return formatted, nil
 			}
 		}
 	}
+	if matched {
+		return lastResult, nil
+	}
 	return "", fmt.Errorf("no matching rule found for host %s", host)
 }
 
-// Generate SHA-256 hash of the given string
-func generateSignature(input string) string {
-	hash := sha256.Sum256([]byte(input))
-	return hex.EncodeToString(hash[:])
-}
-
 //export GetSignature
 func GetSignature(inputURL string) (string, error) {
 	formattedURL, err := processURL(inputURL)
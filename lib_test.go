@@ -0,0 +1,53 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+// TestProcessURLClearsTemplateErrAfterLaterMatch guards against a stale
+// templateErr flag surviving past a later template's success: with
+// stop_at_first_match: false, an earlier template's execute error must not
+// get reported as processURL's final metrics outcome if a later template
+// in the same site goes on to match and format successfully.
+func TestProcessURLClearsTemplateErrAfterLaterMatch(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: example.com
+    stop_at_first_match: false
+    templates:
+      - template: "https://example.com/bad/{{.Slug.Bogus}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+      - template: "https://example.com/ok/{{.Slug}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	var gotMatched, gotTemplateErr bool
+	metricsHook = func(domain string, matched, templateErr bool, duration time.Duration) {
+		gotMatched, gotTemplateErr = matched, templateErr
+	}
+	defer func() { metricsHook = nil }()
+
+	got, err := processURL("https://example.com/articles/hello-world")
+	if err != nil {
+		t.Fatalf("processURL failed: %v", err)
+	}
+	if want := "https://example.com/ok/hello-world"; got != want {
+		t.Fatalf("processURL = %q, want %q", got, want)
+	}
+	if !gotMatched {
+		t.Errorf("metricsHook matched = false, want true")
+	}
+	if gotTemplateErr {
+		t.Errorf("metricsHook templateErr = true, want false (later template succeeded)")
+	}
+}
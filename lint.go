@@ -0,0 +1,252 @@
+//go:build !js
+// +build !js
+
+package main // Don't build when target is wasm
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// lintCase is the outcome of running one RuleTestCase through
+// processURL/generateSignature.
+type lintCase struct {
+	Domain    string `json:"domain"`
+	URL       string `json:"url"`
+	Status    string `json:"status"` // pass, fail, xfail
+	Message   string `json:"message,omitempty"`
+	Expected  string `json:"expected"`
+	Got       string `json:"got"`
+	Signature string `json:"signature,omitempty"`
+	GotSig    string `json:"got_signature,omitempty"`
+}
+
+// runLint is the entry point for `suola lint` (aka `suola test`): it loads
+// a rules file, runs every SiteRule.Tests case through processURL and
+// generateSignature, and reports pass/fail/xfail. With -update it also
+// rewrites the expected/signature fields of non-xfail cases in place to the
+// actual output before reporting.
+func runLint(args []string) error {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	configPath := fs.String("config", "rules.yaml", "Path to YAML configuration file")
+	jsonOut := fs.Bool("json", false, "Emit machine-readable JSON instead of a TAP report")
+	update := fs.Bool("update", false, "Rewrite expected/signature fields in place to match actual output")
+	failOnUnmatched := fs.Bool("fail-on-unmatched", false, "Fail if any site has zero test cases")
+	filter := fs.String("filter", "", "Only lint sites whose domain matches this glob")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	raw := mustReadConfig(*configPath)
+	if err := LoadRules(raw); err != nil {
+		return fmt.Errorf("loading %s: %w", *configPath, err)
+	}
+
+	var results []lintCase
+	unmatchedSites := 0
+
+	for _, site := range GetRules().Sites {
+		if *filter != "" {
+			ok, err := path.Match(*filter, site.Domain)
+			if err != nil {
+				return fmt.Errorf("invalid --filter pattern: %w", err)
+			}
+			if !ok {
+				continue
+			}
+		}
+
+		if len(site.Tests) == 0 {
+			unmatchedSites++
+		}
+		for _, tc := range site.Tests {
+			results = append(results, lintTest(site.Domain, tc))
+		}
+	}
+
+	if *update {
+		updated, changed, rewrittenURLs := applyLintUpdates(raw, results)
+		if changed {
+			if err := os.WriteFile(*configPath, updated, 0o644); err != nil {
+				return fmt.Errorf("writing %s: %w", *configPath, err)
+			}
+		}
+		// The file on disk now matches Got/GotSig for every case whose line
+		// applyLintUpdates actually located and rewrote - reflect that in
+		// the results used for the report and the exit status below. A case
+		// whose url: line carries a trailing comment or odd quoting may never
+		// get matched by applyLintUpdates, so its stale failure must keep
+		// reporting as a failure rather than being blanket-flipped to "pass".
+		for i := range results {
+			if results[i].Status == "fail" && rewrittenURLs[results[i].URL] {
+				results[i].Expected = results[i].Got
+				results[i].Signature = results[i].GotSig
+				results[i].Status = "pass"
+				results[i].Message = ""
+			}
+		}
+	}
+
+	if *jsonOut {
+		if err := json.NewEncoder(os.Stdout).Encode(results); err != nil {
+			return err
+		}
+	} else {
+		printTAP(results)
+	}
+
+	failed := 0
+	for _, r := range results {
+		if r.Status == "fail" {
+			failed++
+		}
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d test(s) failed", failed, len(results))
+	}
+	if *failOnUnmatched && unmatchedSites > 0 {
+		return fmt.Errorf("%d site(s) have no test cases", unmatchedSites)
+	}
+	return nil
+}
+
+// lintTest runs a single test case and classifies the result.
+func lintTest(domain string, tc RuleTestCase) lintCase {
+	r := lintCase{Domain: domain, URL: tc.Url, Expected: tc.Expected, Signature: tc.Signature}
+
+	got, err := processURL(tc.Url)
+	r.Got = got
+
+	if tc.XFail {
+		if err != nil || got != tc.Expected {
+			r.Status = "xfail"
+			r.Message = "expected failure"
+		} else {
+			r.Status = "fail"
+			r.Message = "marked xfail but the rule matched anyway"
+		}
+		return r
+	}
+
+	if err != nil {
+		r.Status = "fail"
+		r.Message = err.Error()
+		return r
+	}
+	if tc.Signature != "" {
+		r.GotSig = generateSignature(got)
+	}
+	if got != tc.Expected {
+		r.Status = "fail"
+		r.Message = fmt.Sprintf("expected %q, got %q", tc.Expected, got)
+		return r
+	}
+
+	if tc.Signature != "" {
+		if r.GotSig != tc.Signature {
+			r.Status = "fail"
+			r.Message = fmt.Sprintf("signature mismatch: expected %s, got %s", tc.Signature, r.GotSig)
+			return r
+		}
+	}
+
+	r.Status = "pass"
+	return r
+}
+
+// printTAP renders results as a TAP (Test Anything Protocol) stream.
+func printTAP(results []lintCase) {
+	fmt.Printf("1..%d\n", len(results))
+	for i, r := range results {
+		n := i + 1
+		switch r.Status {
+		case "pass":
+			fmt.Printf("ok %d - %s %s\n", n, r.Domain, r.URL)
+		case "xfail":
+			fmt.Printf("ok %d - %s %s # TODO known failure\n", n, r.Domain, r.URL)
+		default:
+			fmt.Printf("not ok %d - %s %s: %s\n", n, r.Domain, r.URL, r.Message)
+		}
+	}
+}
+
+var (
+	lintURLLineRe       = regexp.MustCompile(`^(\s*-\s*url:\s*)(.*)$`)
+	lintExpectedLineRe  = regexp.MustCompile(`^(\s*expected:\s*)(.*)$`)
+	lintSignatureLineRe = regexp.MustCompile(`^(\s*signature:\s*)(.*)$`)
+)
+
+// applyLintUpdates rewrites the expected/signature values of non-xfail
+// test cases directly in the original YAML text, so comments and
+// formatting elsewhere in the file survive (a full yaml.Marshal round trip
+// would drop them). It returns the rewritten YAML, whether any bytes
+// changed, and the set of case URLs whose expected/signature line was
+// actually located in the text - matching is by exact string equality on
+// the `- url:` value, so a line with a trailing comment or unusual quoting
+// may never be found, and every case sharing a URL is rewritten from the
+// last non-xfail result for that URL. Callers must only
+// treat a case as fixed if its URL appears in that set, not merely because
+// something else in the file changed.
+func applyLintUpdates(raw []byte, results []lintCase) (updated []byte, changed bool, rewrittenURLs map[string]bool) {
+	byURL := make(map[string]lintCase, len(results))
+	for _, r := range results {
+		if r.Status != "xfail" {
+			byURL[r.URL] = r
+		}
+	}
+
+	lines := strings.Split(string(raw), "\n")
+	var current *lintCase
+	rewrittenURLs = make(map[string]bool)
+
+	for i, line := range lines {
+		if m := lintURLLineRe.FindStringSubmatch(line); m != nil {
+			if r, ok := byURL[unquoteYAMLScalar(strings.TrimSpace(m[2]))]; ok {
+				rCopy := r
+				current = &rCopy
+			} else {
+				current = nil
+			}
+			continue
+		}
+		if current == nil {
+			continue
+		}
+		if m := lintExpectedLineRe.FindStringSubmatch(line); m != nil {
+			if newLine := m[1] + quoteYAMLScalar(current.Got); newLine != line {
+				lines[i] = newLine
+				changed = true
+			}
+			rewrittenURLs[current.URL] = true
+			continue
+		}
+		if current.Signature != "" {
+			if m := lintSignatureLineRe.FindStringSubmatch(line); m != nil {
+				if newLine := m[1] + quoteYAMLScalar(current.GotSig); newLine != line {
+					lines[i] = newLine
+					changed = true
+				}
+				rewrittenURLs[current.URL] = true
+			}
+		}
+	}
+
+	return []byte(strings.Join(lines, "\n")), changed, rewrittenURLs
+}
+
+func quoteYAMLScalar(s string) string {
+	return strconv.Quote(s)
+}
+
+func unquoteYAMLScalar(s string) string {
+	if unquoted, err := strconv.Unquote(s); err == nil {
+		return unquoted
+	}
+	return strings.Trim(s, `'"`)
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestLintTestStatuses(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: example.com
+    templates:
+      - template: "https://example.com/regex/{{.Slug}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+    tests:
+      - url: "https://example.com/articles/hello-world"
+        expected: "https://example.com/regex/hello-world"
+      - url: "https://example.com/articles/stale"
+        expected: "https://example.com/regex/WRONG"
+      - url: "https://example.com/not-a-match"
+        expected: ""
+        xfail: true
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	site := GetRules().Sites[0]
+	if len(site.Tests) != 3 {
+		t.Fatalf("expected 3 test cases, got %d", len(site.Tests))
+	}
+
+	want := []string{"pass", "fail", "xfail"}
+	for i, tc := range site.Tests {
+		r := lintTest(site.Domain, tc)
+		if r.Status != want[i] {
+			t.Errorf("case %d (%s): status = %q, want %q", i, tc.Url, r.Status, want[i])
+		}
+	}
+}
+
+// TestApplyLintUpdatesOnlyFlipsLocatedCases reproduces a two-case file where
+// only one case's `- url:` line can actually be matched back by
+// applyLintUpdates - the other carries a trailing comment, so its exact
+// lookup key never matches. runLint's post-update status recompute must
+// only report the located case as fixed; the unlocated one's stale
+// expected value is still wrong on disk and must keep reporting "fail".
+func TestApplyLintUpdatesOnlyFlipsLocatedCases(t *testing.T) {
+	const yamlDoc = `sites:
+  - domain: example.com
+    templates:
+      - template: "https://example.com/regex/{{.Slug}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+    tests:
+      - url: "https://example.com/articles/hello-world"
+        expected: "https://example.com/regex/WRONG"
+      - url: "https://example.com/articles/other" # keep this comment
+        expected: "https://example.com/regex/WRONG"
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	site := GetRules().Sites[0]
+	results := []lintCase{
+		lintTest(site.Domain, site.Tests[0]),
+		lintTest(site.Domain, site.Tests[1]),
+	}
+	for i, r := range results {
+		if r.Status != "fail" {
+			t.Fatalf("case %d: expected fail before update, got %q", i, r.Status)
+		}
+	}
+
+	updated, changed, rewrittenURLs := applyLintUpdates([]byte(yamlDoc), results)
+	if !changed {
+		t.Fatalf("expected applyLintUpdates to report a change")
+	}
+	if !rewrittenURLs[results[0].URL] {
+		t.Fatalf("expected %s to be located and rewritten", results[0].URL)
+	}
+	if rewrittenURLs[results[1].URL] {
+		t.Fatalf("expected %s (commented url: line) to NOT be located", results[1].URL)
+	}
+
+	out := string(updated)
+	if !strings.Contains(out, `expected: "https://example.com/regex/hello-world"`) {
+		t.Fatalf("expected the located case's value to be rewritten:\n%s", out)
+	}
+	if !strings.Contains(out, `expected: "https://example.com/regex/WRONG"`) {
+		t.Fatalf("expected the unlocated case's stale value to survive untouched:\n%s", out)
+	}
+
+	// Mirror runLint's own recompute logic: only a located case may flip to
+	// "pass" - the unlocated one must keep failing so a CI run doesn't
+	// report success while its fixture is still wrong.
+	for i := range results {
+		if results[i].Status == "fail" && rewrittenURLs[results[i].URL] {
+			results[i].Status = "pass"
+		}
+	}
+	if results[0].Status != "pass" {
+		t.Errorf("located case: status = %q, want pass", results[0].Status)
+	}
+	if results[1].Status != "fail" {
+		t.Errorf("unlocated case: status = %q, want fail (file on disk is still wrong)", results[1].Status)
+	}
+}
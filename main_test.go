@@ -14,7 +14,7 @@ func TestExtractionRules(t *testing.T) {
 		t.Fatalf("Failed to load config: %v", err)
 	}
 
-	for _, site := range Rules.Sites {
+	for _, site := range GetRules().Sites {
 		for _, test := range site.Tests {
 			t.Run(fmt.Sprintf("%s/%s", site.Domain, test.Url), func(t *testing.T) {
 				var hashed = ""
@@ -0,0 +1,206 @@
+//go:build !js
+// +build !js
+
+package main // Don't build when target is wasm
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// Per-domain counters and a processURL latency histogram, published on
+// /metrics. Wired up to lib.go's processURL via metricsHook so the core
+// matching logic stays free of a prometheus dependency.
+var (
+	matchCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "suola_matches_total",
+		Help: "URLs successfully matched and rewritten, by domain.",
+	}, []string{"domain"})
+	noMatchCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "suola_no_match_total",
+		Help: "URLs with no matching rule, by domain.",
+	}, []string{"domain"})
+	templateErrorCounter = promauto.NewCounterVec(prometheus.CounterOpts{
+		Name: "suola_template_errors_total",
+		Help: "Template execution errors, by domain.",
+	}, []string{"domain"})
+	processURLDuration = promauto.NewHistogram(prometheus.HistogramOpts{
+		Name:    "suola_process_url_duration_seconds",
+		Help:    "processURL latency in seconds.",
+		Buckets: prometheus.DefBuckets,
+	})
+)
+
+func recordMetrics(domain string, matched, templateErr bool, duration time.Duration) {
+	if domain == "" {
+		domain = "unknown"
+	}
+	switch {
+	case templateErr:
+		templateErrorCounter.WithLabelValues(domain).Inc()
+	case matched:
+		matchCounter.WithLabelValues(domain).Inc()
+	default:
+		noMatchCounter.WithLabelValues(domain).Inc()
+	}
+	processURLDuration.Observe(duration.Seconds())
+}
+
+type normalizeRequest struct {
+	URL string `json:"url"`
+}
+
+type normalizeResponse struct {
+	URL string `json:"url"`
+}
+
+type signRequest struct {
+	URL string `json:"url"`
+}
+
+type signResponse struct {
+	URL       string `json:"url"`
+	Signature string `json:"signature"`
+}
+
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	_ = json.NewEncoder(w).Encode(v)
+}
+
+func handleNormalize(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req normalizeRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	formatted, err := processURL(req.URL)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, normalizeResponse{URL: formatted})
+}
+
+func handleSign(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	var req signRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, http.StatusBadRequest, errorResponse{Error: err.Error()})
+		return
+	}
+	formatted, err := processURL(req.URL)
+	if err != nil {
+		writeJSON(w, http.StatusUnprocessableEntity, errorResponse{Error: err.Error()})
+		return
+	}
+	writeJSON(w, http.StatusOK, signResponse{URL: formatted, Signature: generateSignature(formatted)})
+}
+
+func handleHealthz(w http.ResponseWriter, r *http.Request) {
+	w.WriteHeader(http.StatusOK)
+	_, _ = w.Write([]byte("ok"))
+}
+
+// watchConfig watches configPath for changes and atomically reloads the
+// rules whenever it is written, created or renamed, so operators can edit rules.yaml without restarting the
+// server. Errors are logged but never fatal; the previous rules stay live.
+func watchConfig(configPath string) error {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return fmt.Errorf("creating config watcher: %w", err)
+	}
+
+	// Watch the containing directory rather than configPath itself: editors
+	// and tools that save via atomic rename (vim, `mv tmp rules.yaml`, a
+	// Kubernetes ConfigMap symlink swap) replace the inode backing the
+	// watched path, and fsnotify stops delivering events for a path once
+	// that happens. A directory watch survives the swap since the directory
+	// itself never goes away; we just filter down to events for configPath.
+	dir := filepath.Dir(configPath)
+	if err := watcher.Add(dir); err != nil {
+		watcher.Close()
+		return fmt.Errorf("watching %s: %w", dir, err)
+	}
+
+	go func() {
+		defer watcher.Close()
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				if filepath.Clean(event.Name) != filepath.Clean(configPath) {
+					continue
+				}
+				if event.Op&(fsnotify.Write|fsnotify.Create|fsnotify.Rename) == 0 {
+					continue
+				}
+				data, err := readConfig(configPath)
+				if err != nil {
+					// Atomic replace leaves a brief window where configPath
+					// is missing or not yet fully written; keep serving the
+					// previous rules rather than taking the process down.
+					fmt.Println("Failed to read config, keeping previous rules:", err)
+					continue
+				}
+				if err := LoadRules(data); err != nil {
+					fmt.Println("Failed to reload config, keeping previous rules:", err)
+					continue
+				}
+				fmt.Printf("Reloaded config from %s with %d sites\n", configPath, len(GetRules().Sites))
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				fmt.Println("Config watcher error:", err)
+			}
+		}
+	}()
+
+	return nil
+}
+
+// runServer exposes processURL and generateSignature over HTTP as
+// POST /normalize and POST /sign (JSON in/out), GET /healthz, and GET
+// /metrics for Prometheus. If configPath is non-empty it is watched for
+// changes so rules.yaml can be edited without restarting the process.
+func runServer(listenAddr, configPath string) error {
+	metricsHook = recordMetrics
+
+	if configPath != "" {
+		if err := watchConfig(configPath); err != nil {
+			fmt.Println("Hot-reload disabled:", err)
+		}
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/normalize", handleNormalize)
+	mux.HandleFunc("/sign", handleSign)
+	mux.HandleFunc("/healthz", handleHealthz)
+	mux.Handle("/metrics", promhttp.Handler())
+
+	fmt.Printf("[🧂 suola]: Listening on %s\n", listenAddr)
+	return http.ListenAndServe(listenAddr, mux)
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"net/url"
+	"os"
+	"strings"
+
+	"lukechampine.com/blake3"
+)
+
+// SignatureConfig selects the hashing algorithm used to sign a formatted
+// URL. Algo defaults to sha256 when empty. KeyEnv names the environment
+// variable holding the key for hmac-sha256. Encoding defaults to hex.
+type SignatureConfig struct {
+	Algo     string `yaml:"algo"`     // sha256 (default), sha512, blake3, hmac-sha256
+	KeyEnv   string `yaml:"key_env"`  // env var holding the HMAC key, for algo: hmac-sha256
+	Encoding string `yaml:"encoding"` // hex (default) or base64url
+}
+
+// Hasher computes a signature over a formatted URL.
+type Hasher interface {
+	Name() string
+	Sum(data []byte) []byte
+}
+
+type sha256Hasher struct{}
+
+func (sha256Hasher) Name() string { return "sha256" }
+func (sha256Hasher) Sum(data []byte) []byte {
+	sum := sha256.Sum256(data)
+	return sum[:]
+}
+
+type sha512Hasher struct{}
+
+func (sha512Hasher) Name() string { return "sha512" }
+func (sha512Hasher) Sum(data []byte) []byte {
+	sum := sha512.Sum512(data)
+	return sum[:]
+}
+
+type blake3Hasher struct{}
+
+func (blake3Hasher) Name() string { return "blake3" }
+func (blake3Hasher) Sum(data []byte) []byte {
+	sum := blake3.Sum256(data)
+	return sum[:]
+}
+
+// hmacSHA256Hasher signs with a shared key so deployments can rotate keys
+// via the environment without recompiling.
+type hmacSHA256Hasher struct{ key []byte }
+
+func (hmacSHA256Hasher) Name() string { return "hmac-sha256" }
+func (h hmacSHA256Hasher) Sum(data []byte) []byte {
+	mac := hmac.New(sha256.New, h.key)
+	mac.Write(data)
+	return mac.Sum(nil)
+}
+
+// newHasher builds the Hasher for cfg, reading the HMAC key from the
+// environment when needed.
+func newHasher(cfg SignatureConfig) (Hasher, error) {
+	switch strings.ToLower(cfg.Algo) {
+	case "", "sha256":
+		return sha256Hasher{}, nil
+	case "sha512":
+		return sha512Hasher{}, nil
+	case "blake3":
+		return blake3Hasher{}, nil
+	case "hmac-sha256":
+		key := os.Getenv(cfg.KeyEnv)
+		if key == "" {
+			return nil, fmt.Errorf("hmac-sha256 requires env var %q to be set", cfg.KeyEnv)
+		}
+		return hmacSHA256Hasher{key: []byte(key)}, nil
+	default:
+		return nil, fmt.Errorf("unknown signature algorithm: %s", cfg.Algo)
+	}
+}
+
+// encodeSignature renders a raw signature per cfg.Encoding.
+func encodeSignature(sum []byte, encoding string) string {
+	if strings.EqualFold(encoding, "base64url") {
+		return base64.RawURLEncoding.EncodeToString(sum)
+	}
+	return hex.EncodeToString(sum)
+}
+
+// signatureConfigForURL resolves the effective SignatureConfig for a
+// formatted URL: a matching site's own `signature` block, falling back to
+// the top-level default in Config.
+func signatureConfigForURL(formattedURL string) SignatureConfig {
+	cfg := GetRules()
+	if cfg == nil {
+		return SignatureConfig{}
+	}
+
+	if parsed, err := url.Parse(formattedURL); err == nil {
+		for _, site := range cfg.Sites {
+			if site.Signature != nil && strings.HasSuffix(parsed.Host, site.Domain) {
+				return *site.Signature
+			}
+		}
+	}
+
+	return cfg.Signature
+}
+
+// Generate a signature for the given string using the configured algorithm,
+// per-site if one applies, otherwise the global default.
+func generateSignature(input string) string {
+	cfg := signatureConfigForURL(input)
+	hasher, err := newHasher(cfg)
+	if err != nil {
+		fmt.Println("Invalid signature config, falling back to sha256:", err)
+		hasher = sha256Hasher{}
+	}
+	return encodeSignature(hasher.Sum([]byte(input)), cfg.Encoding)
+}
+
+// VerifySignature reports whether sig is the valid signature for inputURL,
+// comparing in constant time. inputURL is expected to be the canonical URL
+// that processURL produced (handleSign returns it alongside the signature),
+// not the original messy input — processURL's rules match the latter's shape, not the former's,
+// so this hashes inputURL directly rather than re-running it through
+// processURL. This is what unlocks tamper-evident URL sharing: a recipient
+// can verify a shared link without re-deriving it.
+func VerifySignature(inputURL, sig string) (bool, error) {
+	expected := generateSignature(inputURL)
+	return hmac.Equal([]byte(expected), []byte(sig)), nil
+}
@@ -0,0 +1,45 @@
+package main
+
+import "testing"
+
+func TestVerifySignatureRoundTrip(t *testing.T) {
+	const yamlDoc = `
+sites:
+  - domain: example.com
+    templates:
+      - template: "https://example.com/regex/{{.Slug}}"
+        extractors:
+          - type: regex
+            part: path
+            value: "^/articles/(?P<Slug>[^/]+)"
+`
+	if err := LoadRules([]byte(yamlDoc)); err != nil {
+		t.Fatalf("LoadRules failed: %v", err)
+	}
+
+	// The signature covers the *canonical* URL processURL produces (handleSign
+	// returns the two together) - that's what a recipient is expected to
+	// verify, not the original messy input, since processURL's rules match the
+	// latter's shape only.
+	canonical, err := processURL("https://example.com/articles/hello-world")
+	if err != nil {
+		t.Fatalf("processURL failed: %v", err)
+	}
+	sig := generateSignature(canonical)
+
+	ok, err := VerifySignature(canonical, sig)
+	if err != nil {
+		t.Fatalf("VerifySignature failed: %v", err)
+	}
+	if !ok {
+		t.Fatalf("VerifySignature(%q, %q) = false, want true", canonical, sig)
+	}
+
+	ok, err = VerifySignature(canonical, sig+"00")
+	if err != nil {
+		t.Fatalf("VerifySignature failed: %v", err)
+	}
+	if ok {
+		t.Fatalf("VerifySignature accepted a tampered signature")
+	}
+}
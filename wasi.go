@@ -47,9 +47,36 @@
 //
 //	# Step 8: Free only the input buffer we allocated with Malloc
 //	free_fn(store, url_ptr)  # Free input only, NOT sig_ptr!
+//
+// Batch wire format (GetSignatures):
+//
+// Processing one URL per host<->guest round trip is expensive from Python
+// via wasmtime-py for large corpora, so GetSignatures(ptr, len) accepts and
+// returns a single length-prefixed batch instead. All integers are
+// little-endian.
+//
+// Request buffer at ptr (len bytes total):
+//
+//	uint32 count
+//	count * {
+//	    uint32 length
+//	    byte[length] url
+//	}
+//
+// Response buffer, returned packed the same way as GetSignature (pointer in
+// the high 32 bits, length in the low 32 bits of the returned uint64), one
+// record per input URL in the same order:
+//
+//	count * {
+//	    uint32 length       // length of the bytes that follow
+//	    uint8  error_flag   // 0 = bytes is the signature, 1 = bytes is an error message
+//	    byte[length] bytes
+//	}
 package main
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"os"
 	"sync"
@@ -65,7 +92,11 @@ const maxAllocSize = 1024 * 1024 // 1MB
 // Using a sync.Map for better concurrent performance
 var memoryArena sync.Map // map[uint32][]byte
 
-// GetSignature processes a URL and returns a signature.
+// wasiGetSignature processes a URL and returns a signature. Named
+// distinctly from the Go API's exported GetSignature (lib.go), which this
+// package also always compiles in, so the two don't collide on the same
+// top-level identifier in package main - same rationale as
+// wasiVerifySignature below.
 //
 // Parameters:
 //   - urlPtr: Pointer to URL string in WASM memory (allocated by caller with Malloc)
@@ -82,11 +113,11 @@ var memoryArena sync.Map // map[uint32][]byte
 // Note: The returned pointer is managed by Go's memory arena and should NOT be freed by the caller.
 //
 //go:wasmexport GetSignature
-func GetSignature(urlPtr, urlLen uint32) uint64 {
+func wasiGetSignature(urlPtr, urlLen uint32) uint64 {
 	// Read the URL string from WASM memory
 	url := ptrToString(urlPtr, urlLen)
 
-	signature, err := getSignature(url)
+	signature, err := GetSignature(url)
 
 	if err != nil {
 		// Return error indicator: pointer to error message with error bit set
@@ -103,6 +134,139 @@ func GetSignature(urlPtr, urlLen uint32) uint64 {
 	return uint64(sigPtr)<<32 | uint64(sigLen)
 }
 
+// wasiVerifySignature checks a previously generated signature against a
+// URL. Named distinctly from the Go API's exported VerifySignature
+// (signature.go), which this package also always compiles in, so the two
+// don't collide on the same top-level identifier.
+//
+// Parameters:
+//   - urlPtr, urlLen: Pointer/length of the URL string in WASM memory
+//   - sigPtr, sigLen: Pointer/length of the signature string to check
+//
+// Returns: uint64 packed the same way as GetSignature - a pointer/length
+// pair to either "true" or "false" on success, or an error message with
+// bit 31 of the length set on failure.
+//
+//go:wasmexport VerifySignature
+func wasiVerifySignature(urlPtr, urlLen, sigPtr, sigLen uint32) uint64 {
+	url := ptrToString(urlPtr, urlLen)
+	sig := ptrToString(sigPtr, sigLen)
+
+	ok, err := VerifySignature(url, sig)
+	if err != nil {
+		errMsg := err.Error()
+		errPtr, errLen := stringToPtr(errMsg)
+		return uint64(errPtr)<<32 | uint64(errLen|0x80000000)
+	}
+
+	result := "false"
+	if ok {
+		result = "true"
+	}
+	resPtr, resLen := stringToPtr(result)
+	return uint64(resPtr)<<32 | uint64(resLen)
+}
+
+// GetSignatures processes a length-prefixed batch of URLs in a single
+// host<->guest round trip. See the wire format documented at the top of
+// this file.
+//
+//go:wasmexport GetSignatures
+func GetSignatures(ptr, length uint32) uint64 {
+	buf := ptrToBytes(ptr, length)
+	if buf == nil {
+		errPtr, errLen := stringToPtr("invalid batch buffer")
+		return uint64(errPtr)<<32 | uint64(errLen|0x80000000)
+	}
+
+	count, rest, err := readUint32(buf)
+	if err != nil {
+		errPtr, errLen := stringToPtr(err.Error())
+		return uint64(errPtr)<<32 | uint64(errLen|0x80000000)
+	}
+
+	var out bytes.Buffer
+	for i := uint32(0); i < count; i++ {
+		var recordLen uint32
+		recordLen, rest, err = readUint32(rest)
+		if err != nil {
+			errPtr, errLen := stringToPtr(fmt.Sprintf("record %d: %v", i, err))
+			return uint64(errPtr)<<32 | uint64(errLen|0x80000000)
+		}
+		if uint32(len(rest)) < recordLen {
+			errPtr, errLen := stringToPtr(fmt.Sprintf("record %d: truncated URL", i))
+			return uint64(errPtr)<<32 | uint64(errLen|0x80000000)
+		}
+
+		url := string(rest[:recordLen])
+		rest = rest[recordLen:]
+
+		formatted, procErr := processURL(url)
+		if procErr != nil {
+			writeBatchRecord(&out, []byte(procErr.Error()), true)
+			continue
+		}
+		writeBatchRecord(&out, []byte(generateSignature(formatted)), false)
+	}
+
+	resPtr, resLen := bytesToPtr(out.Bytes())
+	return uint64(resPtr)<<32 | uint64(resLen)
+}
+
+// readUint32 reads a little-endian uint32 off the front of buf, returning
+// the remaining bytes.
+func readUint32(buf []byte) (uint32, []byte, error) {
+	if len(buf) < 4 {
+		return 0, nil, fmt.Errorf("truncated batch buffer")
+	}
+	return binary.LittleEndian.Uint32(buf[:4]), buf[4:], nil
+}
+
+// writeBatchRecord appends one `uint32 length || uint8 error_flag || bytes`
+// record to out.
+func writeBatchRecord(out *bytes.Buffer, payload []byte, isError bool) {
+	var lenBuf [4]byte
+	binary.LittleEndian.PutUint32(lenBuf[:], uint32(len(payload)))
+	out.Write(lenBuf[:])
+	if isError {
+		out.WriteByte(1)
+	} else {
+		out.WriteByte(0)
+	}
+	out.Write(payload)
+}
+
+// Helper to convert pointer and length to a Go []byte, honoring the same
+// bounds checks as ptrToString but without the 64KB URL-sized cap.
+func ptrToBytes(ptr, length uint32) []byte {
+	if length == 0 {
+		return []byte{}
+	}
+	if length > maxAllocSize {
+		return nil
+	}
+	if ptr == 0 || ptr > 0xFFFFFF {
+		return nil
+	}
+	return unsafe.Slice((*byte)(unsafe.Pointer(uintptr(ptr))), length)
+}
+
+// Helper to allocate a []byte in WASM memory and return pointer + length,
+// keeping the allocation alive in memoryArena like stringToPtr does.
+func bytesToPtr(data []byte) (uint32, uint32) {
+	if len(data) == 0 {
+		return 0, 0
+	}
+	if len(data) > 0x7FFFFFFF {
+		return 0, 0
+	}
+
+	ptr := uint32(uintptr(unsafe.Pointer(&data[0])))
+	memoryArena.Store(ptr, data)
+
+	return ptr, uint32(len(data))
+}
+
 // Helper to convert pointer and length to Go string
 func ptrToString(ptr, length uint32) string {
 	if length == 0 {
@@ -161,6 +325,28 @@ func Malloc(size uint32) uint32 {
 	return ptr
 }
 
+// MallocAligned allocates size bytes aligned to align bytes (align must be
+// a power of two). Useful for batch buffers the host wants to populate with
+// fixed-width fields without worrying about alignment faults on its side.
+//
+//go:wasmexport MallocAligned
+func MallocAligned(size, align uint32) uint32 {
+	if size == 0 || size > maxAllocSize || align == 0 || align&(align-1) != 0 {
+		return 0
+	}
+
+	buf := make([]byte, size+align-1)
+	base := uintptr(unsafe.Pointer(&buf[0]))
+	aligned := (base + uintptr(align-1)) &^ uintptr(align-1)
+	slice := buf[aligned-base:]
+	slice = slice[:size:size]
+
+	ptr := uint32(uintptr(unsafe.Pointer(&slice[0])))
+	memoryArena.Store(ptr, slice)
+
+	return ptr
+}
+
 //go:wasmexport Free
 func Free(ptr uint32) {
 	// Remove from memory arena to allow GC
@@ -42,7 +42,7 @@ func TestWasiProgram(t *testing.T) {
 	}
 
 	// Loop through all the test cases in rules.yaml
-	for _, site := range Rules.Sites {
+	for _, site := range GetRules().Sites {
 		for _, testCase := range site.Tests {
 			testName := site.Domain
 